@@ -0,0 +1,133 @@
+package torchwood
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+// memTiles is an in-memory [TileReaderWithContext] backing a single
+// fake log, built by [newTestLog]. It serves the one data tile and every
+// hash tile a [Client] or [VerifyingClient] can ask for, without any
+// network access, so that the verification logic in tlogclient.go and
+// verifyingclient.go can be exercised against known-good and tampered
+// data.
+//
+// The fixture only supports logs small enough to fit in a single data
+// tile (tile number 0), which is all the tests in this package need.
+type memTiles struct {
+	data        []byte
+	dataWidth   int
+	levelHashes map[int][]tlog.Hash
+}
+
+func (m *memTiles) ReadTiles(ctx context.Context, tiles []tlog.Tile) ([][]byte, error) {
+	out := make([][]byte, len(tiles))
+	for i, t := range tiles {
+		if t.L == -1 {
+			if t.N != 0 || t.W != m.dataWidth {
+				return nil, fmt.Errorf("memTiles: unexpected data tile request %+v", t)
+			}
+			out[i] = m.data
+			continue
+		}
+		// Hash tile L holds the tree's hashes at level L*H.
+		hashes := m.levelHashes[t.L*t.H]
+		if t.N != 0 || t.W > len(hashes) {
+			return nil, fmt.Errorf("memTiles: unexpected hash tile request %+v", t)
+		}
+		var buf bytes.Buffer
+		for _, h := range hashes[:t.W] {
+			buf.Write(h[:])
+		}
+		out[i] = buf.Bytes()
+	}
+	return out, nil
+}
+
+func (m *memTiles) SaveTiles(tiles []tlog.Tile, data [][]byte) {}
+
+// hashReaderFunc adapts a plain function to [tlog.HashReader].
+type hashReaderFunc func([]int64) ([]tlog.Hash, error)
+
+func (f hashReaderFunc) ReadHashes(indexes []int64) ([]tlog.Hash, error) { return f(indexes) }
+
+// newTestLog builds an in-memory log of n leaves (n must be <= TileWidth,
+// so it fits in a single data tile) in the go.dev/design/25530-sumdb
+// entry format used by [WithSumDBEntries], and returns its [tlog.Tree]
+// alongside the [memTiles] that serves it. Two logs built with the same
+// label contain the same leaves up to the size of the smaller one, so
+// one can be a genuine extension of the other; logs built with
+// different labels never are, even at indexes they happen to share.
+func newTestLog(t *testing.T, label string, n int) (tlog.Tree, *memTiles) {
+	t.Helper()
+
+	entries := make([]string, n)
+	for i := range entries {
+		entries[i] = fmt.Sprintf("%s entry %d", label, i)
+	}
+	data, leafHashes := buildDataTile(entries)
+
+	store := map[int64]tlog.Hash{}
+	levelHashes := map[int][]tlog.Hash{}
+	type node struct {
+		level int
+		n     int64
+		hash  tlog.Hash
+	}
+	var stack []node
+	for i, h := range leafHashes {
+		cur := node{level: 0, n: int64(i), hash: h}
+		store[tlog.StoredHashIndex(cur.level, cur.n)] = cur.hash
+		levelHashes[cur.level] = append(levelHashes[cur.level], cur.hash)
+		for len(stack) > 0 && stack[len(stack)-1].level == cur.level {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			cur = node{level: cur.level + 1, n: top.n / 2, hash: tlog.NodeHash(top.hash, cur.hash)}
+			store[tlog.StoredHashIndex(cur.level, cur.n)] = cur.hash
+			levelHashes[cur.level] = append(levelHashes[cur.level], cur.hash)
+		}
+		stack = append(stack, cur)
+	}
+
+	hr := hashReaderFunc(func(indexes []int64) ([]tlog.Hash, error) {
+		out := make([]tlog.Hash, len(indexes))
+		for i, idx := range indexes {
+			h, ok := store[idx]
+			if !ok {
+				return nil, fmt.Errorf("newTestLog: stored hash %d not available for a tree of size %d", idx, n)
+			}
+			out[i] = h
+		}
+		return out, nil
+	})
+	root, err := tlog.TreeHash(int64(n), hr)
+	if err != nil {
+		t.Fatalf("computing test tree hash: %v", err)
+	}
+
+	mt := &memTiles{data: data, dataWidth: n, levelHashes: levelHashes}
+	return tlog.Tree{N: int64(n), Hash: root}, mt
+}
+
+// buildDataTile renders entries into the go.dev/design/25530-sumdb tile
+// format consumed by [WithSumDBEntries], and returns the record hash
+// that cutting each entry back out of that format will produce.
+func buildDataTile(entries []string) (tile []byte, leafHashes []tlog.Hash) {
+	var buf bytes.Buffer
+	leafHashes = make([]tlog.Hash, len(entries))
+	for i, e := range entries {
+		if i < len(entries)-1 {
+			buf.WriteString(e)
+			buf.WriteString("\n\n")
+			leafHashes[i] = tlog.RecordHash([]byte(e + "\n"))
+		} else {
+			buf.WriteString(e)
+			leafHashes[i] = tlog.RecordHash([]byte(e))
+		}
+	}
+	return buf.Bytes(), leafHashes
+}
@@ -0,0 +1,171 @@
+package torchwood
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+// Lookup fetches the single data tile containing index, extracts the entry
+// at that index using the configured cut function, and verifies its record
+// hash against tree. Unlike [Client.Entries], it does not need to walk the
+// tiles between index and the start of the scan, so it's the right tool for
+// verifying individual entries out of order.
+//
+// This is analogous to the /lookup path of the Go sumdb Client, but keyed by
+// leaf index rather than module@version.
+func (c *Client) Lookup(ctx context.Context, tree tlog.Tree, index int64) (entry []byte, err error) {
+	if index < 0 || index >= tree.N {
+		return nil, fmt.Errorf("index %d out of range for tree of size %d", index, tree.N)
+	}
+
+	tile := tlog.Tile{H: TileHeight, L: -1, N: index / TileWidth, W: TileWidth}
+	if top := tile.N*TileWidth + TileWidth; top > tree.N {
+		tile.W = int(tree.N - tile.N*TileWidth)
+	}
+
+	data, err := c.lookups.readTile(ctx, c.tr, tile)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := index - tile.N*TileWidth
+	var rh tlog.Hash
+	for i := int64(0); i <= offset; i++ {
+		if len(data) == 0 {
+			return nil, fmt.Errorf("unexpected end of tile data for tile %d", tile.N)
+		}
+		entry, rh, data, err = c.cut(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cut entry %d: %w", index, err)
+		}
+	}
+
+	hashes, err := TileHashReaderWithContext(ctx, tree, c.tr).ReadHashes([]int64{tlog.StoredHashIndex(0, index)})
+	if err != nil {
+		return nil, err
+	}
+	if rh != hashes[0] {
+		return nil, fmt.Errorf("%w: entry %d", ErrInclusionMismatch, index)
+	}
+	return entry, nil
+}
+
+// LookupAll is like [Client.Lookup], but for a batch of indexes. Lookups
+// that land in the same tile share a single tile fetch, and all the record
+// hashes are verified with a single call to the underlying hash reader.
+func (c *Client) LookupAll(ctx context.Context, tree tlog.Tree, indexes []int64) (entries [][]byte, err error) {
+	entries = make([][]byte, len(indexes))
+	hashIndexes := make([]int64, len(indexes))
+	recordHashes := make([]tlog.Hash, len(indexes))
+	tileData := make(map[tlog.Tile][]byte)
+
+	for i, index := range indexes {
+		if index < 0 || index >= tree.N {
+			return nil, fmt.Errorf("index %d out of range for tree of size %d", index, tree.N)
+		}
+		hashIndexes[i] = tlog.StoredHashIndex(0, index)
+
+		tile := tlog.Tile{H: TileHeight, L: -1, N: index / TileWidth, W: TileWidth}
+		if top := tile.N*TileWidth + TileWidth; top > tree.N {
+			tile.W = int(tree.N - tile.N*TileWidth)
+		}
+		data, ok := tileData[tile]
+		if !ok {
+			data, err = c.lookups.readTile(ctx, c.tr, tile)
+			if err != nil {
+				return nil, err
+			}
+			tileData[tile] = data
+		}
+
+		offset := index - tile.N*TileWidth
+		var entry []byte
+		var rh tlog.Hash
+		for i := int64(0); i <= offset; i++ {
+			if len(data) == 0 {
+				return nil, fmt.Errorf("unexpected end of tile data for tile %d", tile.N)
+			}
+			entry, rh, data, err = c.cut(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to cut entry %d: %w", index, err)
+			}
+		}
+		entries[i] = entry
+		recordHashes[i] = rh
+	}
+
+	hashes, err := TileHashReaderWithContext(ctx, tree, c.tr).ReadHashes(hashIndexes)
+	if err != nil {
+		return nil, err
+	}
+	for i, index := range indexes {
+		if recordHashes[i] != hashes[i] {
+			return nil, fmt.Errorf("%w: entry %d", ErrInclusionMismatch, index)
+		}
+	}
+	return entries, nil
+}
+
+// LookupByKey resolves key to a leaf index using the [IndexResolver]
+// configured with [WithIndexResolver], then calls [Client.Lookup].
+func (c *Client) LookupByKey(ctx context.Context, tree tlog.Tree, key []byte) (entry []byte, err error) {
+	if c.resolver == nil {
+		return nil, fmt.Errorf("torchwood: no IndexResolver configured, see WithIndexResolver")
+	}
+	index, err := c.resolver.ResolveIndex(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("resolving key to index: %w", err)
+	}
+	return c.Lookup(ctx, tree, index)
+}
+
+// parCache coalesces concurrent fetches of the same tile into a single
+// ReadTiles call, mirroring the parCache used by the Go sumdb Client to
+// avoid a burst of nearby lookups each issuing their own tile fetch.
+//
+// Unlike sumdb's parCache, entries are removed as soon as their fetch
+// completes rather than kept forever: Lookup and LookupByKey are meant
+// for scattered, out-of-order reads over the lifetime of a long-running
+// client such as a [monitor.Monitor], and caching every tile ever looked
+// up would grow the cache without bound. Coalescing still works for
+// calls that genuinely race, since they see the in-flight entry before
+// it's removed; a caller that revisits a tile later just issues a new
+// fetch, same as the underlying [TileReaderWithContext] (typically
+// wrapped in a [PermanentCache]) expects.
+type parCache struct {
+	m sync.Map // tlog.Tile -> *parCacheEntry
+}
+
+type parCacheEntry struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+func (c *parCache) readTile(ctx context.Context, tr TileReaderWithContext, tile tlog.Tile) ([]byte, error) {
+	e := &parCacheEntry{done: make(chan struct{})}
+	actual, loaded := c.m.LoadOrStore(tile, e)
+	e = actual.(*parCacheEntry)
+	if loaded {
+		select {
+		case <-e.done:
+			return e.data, e.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	defer c.m.Delete(tile)
+	defer close(e.done)
+	data, err := tr.ReadTiles(ctx, []tlog.Tile{tile})
+	if err != nil {
+		e.err = err
+		return nil, err
+	}
+	e.data = data[0]
+	tr.SaveTiles([]tlog.Tile{tile}, data)
+	return e.data, nil
+}
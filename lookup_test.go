@@ -0,0 +1,159 @@
+package torchwood
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+func TestLookupReturnsEntryAndVerifiesIt(t *testing.T) {
+	tree, mt := newTestLog(t, "main", 10)
+	c, err := NewClient(mt, WithSumDBEntries())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	entry, err := c.Lookup(context.Background(), tree, 3)
+	if err != nil {
+		t.Fatalf("Lookup: %v", err)
+	}
+	if want := "main entry 3"; string(entry) != want {
+		t.Fatalf("Lookup entry = %q, want %q", entry, want)
+	}
+}
+
+func TestLookupRejectsOutOfRangeIndex(t *testing.T) {
+	tree, mt := newTestLog(t, "main", 10)
+	c, err := NewClient(mt, WithSumDBEntries())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	if _, err := c.Lookup(context.Background(), tree, -1); err == nil {
+		t.Fatalf("Lookup(-1) succeeded, want an error")
+	}
+	if _, err := c.Lookup(context.Background(), tree, tree.N); err == nil {
+		t.Fatalf("Lookup(%d) succeeded, want an error", tree.N)
+	}
+}
+
+// TestLookupDetectsTamperedRecordHash tampers with a data tile's bytes,
+// without touching the tree it's meant to match, and checks that
+// [Client.Lookup] refuses to return the corrupted entry and reports
+// [ErrInclusionMismatch].
+func TestLookupDetectsTamperedRecordHash(t *testing.T) {
+	tree, mt := newTestLog(t, "main", 10)
+
+	corrupted := append([]byte(nil), mt.data...)
+	target := []byte("main entry 3")
+	replacement := []byte("MAIN ENTRY 3")
+	idx := indexOf(corrupted, target)
+	if idx < 0 {
+		t.Fatalf("test entry %q not found in tile data", target)
+	}
+	copy(corrupted[idx:], replacement)
+
+	tampered := &memTiles{data: corrupted, dataWidth: mt.dataWidth, levelHashes: mt.levelHashes}
+	c, err := NewClient(tampered, WithSumDBEntries())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	if _, err := c.Lookup(context.Background(), tree, 3); !errors.Is(err, ErrInclusionMismatch) {
+		t.Fatalf("Lookup err = %v, want ErrInclusionMismatch", err)
+	}
+}
+
+// countingTiles wraps a [memTiles], counting calls to ReadTiles that
+// request a data tile and blocking each call on release, so a test can
+// hold a tile fetch open long enough for concurrent callers to land on
+// the same parCache entry instead of each issuing their own fetch. Hash
+// tile reads, made to verify record hashes, are not counted.
+type countingTiles struct {
+	mt      *memTiles
+	release chan struct{}
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *countingTiles) ReadTiles(ctx context.Context, tiles []tlog.Tile) ([][]byte, error) {
+	c.mu.Lock()
+	for _, t := range tiles {
+		if t.L == -1 {
+			c.calls++
+			break
+		}
+	}
+	c.mu.Unlock()
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return c.mt.ReadTiles(ctx, tiles)
+}
+
+func (c *countingTiles) SaveTiles(tiles []tlog.Tile, data [][]byte) {
+	c.mt.SaveTiles(tiles, data)
+}
+
+// TestLookupAllCoalescesConcurrentRequests drives several goroutines'
+// worth of LookupAll calls that all land in the single data tile of a
+// small test log, and checks that parCache coalesces them into a
+// single underlying tile fetch.
+func TestLookupAllCoalescesConcurrentRequests(t *testing.T) {
+	tree, mt := newTestLog(t, "main", 4)
+	ct := &countingTiles{mt: mt, release: make(chan struct{})}
+	c, err := NewClient(ct, WithSumDBEntries())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	const goroutines = 8
+	var ready atomic.Int32
+	var wg sync.WaitGroup
+	errs := make([]error, goroutines)
+	entries := make([][][]byte, goroutines)
+	for i := range goroutines {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ready.Add(1)
+			for ready.Load() < goroutines {
+				// Busy-wait for every goroutine to be about to call
+				// LookupAll, so they race for the same parCache entry
+				// instead of trickling in one at a time.
+			}
+			entries[i], errs[i] = c.LookupAll(context.Background(), tree, []int64{0, 1, 2, 3})
+		}(i)
+	}
+	for ready.Load() < goroutines {
+	}
+	close(ct.release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("LookupAll[%d]: %v", i, err)
+		}
+	}
+	for i := 1; i < goroutines; i++ {
+		for j := range entries[i] {
+			if string(entries[i][j]) != string(entries[0][j]) {
+				t.Fatalf("LookupAll[%d][%d] = %q, want %q", i, j, entries[i][j], entries[0][j])
+			}
+		}
+	}
+
+	ct.mu.Lock()
+	calls := ct.calls
+	ct.mu.Unlock()
+	if calls != 1 {
+		t.Fatalf("underlying data tile fetched %d times, want 1 (parCache should coalesce concurrent requests for the same tile)", calls)
+	}
+}
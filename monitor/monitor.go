@@ -0,0 +1,241 @@
+// Package monitor implements a continuous auditor for a transparency log
+// built on [filippo.io/torchwood]. It polls a log for its latest checkpoint,
+// relies on a [torchwood.VerifyingClient] to verify monotonicity and
+// consistency of the tree, and streams newly observed entries into a
+// caller-supplied [EntrySink].
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"golang.org/x/mod/sumdb/tlog"
+
+	"filippo.io/torchwood"
+)
+
+// CheckpointFetcher fetches the latest checkpoint from a log and returns the
+// [tlog.Tree] it encodes. Implementations are expected to validate the
+// checkpoint's note signatures against a note.Verifiers (see
+// golang.org/x/mod/sumdb/note) before returning, so that the tree returned
+// here has already been authenticated to the log's signing keys.
+type CheckpointFetcher func(ctx context.Context) (tlog.Tree, error)
+
+// EntrySink receives entries observed by a [Monitor]. Store may be called
+// more than once for the same index, so implementations must be idempotent.
+type EntrySink interface {
+	Store(ctx context.Context, index int64, entry []byte, rh tlog.Hash) error
+}
+
+// SecurityEventKind identifies the kind of log misbehavior a [SecurityEvent]
+// reports.
+type SecurityEventKind int
+
+const (
+	// BadCheckpoint means the [CheckpointFetcher] failed to fetch or
+	// authenticate the latest checkpoint.
+	BadCheckpoint SecurityEventKind = iota + 1
+	// ConsistencyFailure means the consistency proof between the last
+	// trusted tree and a new, larger one did not verify, i.e. the log
+	// forked. See [torchwood.ErrLogFork].
+	ConsistencyFailure
+	// InclusionFailure means an entry fetched from the log did not match
+	// the hash recorded in the tree, i.e. the log served a corrupted or
+	// tampered entry.
+	InclusionFailure
+	// SyncFailure means fetching or reading log data failed for reasons
+	// that carry no security implication on their own, such as a network
+	// error or a server returning a 5xx status. Operators may still want to
+	// know about these, but they are not evidence of log misbehavior the
+	// way the other kinds are.
+	SyncFailure
+)
+
+// SecurityEvent reports a verification failure observed while monitoring a
+// log. Operators are expected to page on these rather than let the
+// [Monitor] silently retry forever.
+type SecurityEvent struct {
+	Kind SecurityEventKind
+	Err  error
+}
+
+// treeStateRecorder is implemented by sinks that also want to persist every
+// observed checkpoint, such as [SQLSink]. A [Monitor] calls it once per
+// poll, as soon as the [CheckpointFetcher] returns a tree and before that
+// tree is checked for consistency with the last trusted one.
+type treeStateRecorder interface {
+	RecordTreeState(ctx context.Context, tree tlog.Tree, observedAt time.Time) error
+}
+
+// Monitor continuously tails a log, verifying it as it grows and streaming
+// newly observed entries into an [EntrySink].
+type Monitor struct {
+	fetch      CheckpointFetcher
+	client     *torchwood.VerifyingClient
+	sink       EntrySink
+	interval   time.Duration
+	maxBackoff time.Duration
+	events     chan SecurityEvent
+
+	pos int64
+}
+
+// NewMonitor creates a new [Monitor] that fetches checkpoints with fetch,
+// verifies and reads entries with client, and stores them with sink.
+func NewMonitor(fetch CheckpointFetcher, client *torchwood.VerifyingClient, sink EntrySink, opts ...MonitorOption) *Monitor {
+	m := &Monitor{
+		fetch:      fetch,
+		client:     client,
+		sink:       sink,
+		interval:   time.Minute,
+		maxBackoff: 10 * time.Minute,
+		events:     make(chan SecurityEvent, 16),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// MonitorOption is a function that configures a [Monitor].
+type MonitorOption func(*Monitor)
+
+// WithPollInterval configures how often the [Monitor] polls for a new
+// checkpoint. The default is one minute.
+func WithPollInterval(d time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		m.interval = d
+	}
+}
+
+// WithStartIndex configures the first entry index the [Monitor] will fetch.
+// The default is zero. It has no effect once [Monitor.Run] has been called.
+func WithStartIndex(index int64) MonitorOption {
+	return func(m *Monitor) {
+		m.pos = index
+	}
+}
+
+// WithMaxBackoff caps the delay [Monitor.Run] waits after consecutive
+// [CheckpointFetcher] failures, before trying again. The default is ten
+// minutes.
+func WithMaxBackoff(d time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		m.maxBackoff = d
+	}
+}
+
+// Events returns the channel on which the [Monitor] emits a [SecurityEvent]
+// whenever a checkpoint, consistency proof, or inclusion check fails, or a
+// tile fetch fails for operational reasons. Callers must drain this
+// channel, or Run will block.
+func (m *Monitor) Events() <-chan SecurityEvent {
+	return m.events
+}
+
+// Run polls the log at the configured interval until ctx is canceled,
+// streaming new entries into the [EntrySink]. It returns ctx.Err() when ctx
+// is canceled, or any error returned by the EntrySink; verification
+// failures are reported on the Events channel instead of stopping the loop,
+// so that transient log misbehavior doesn't take the monitor down.
+//
+// Consecutive failures to fetch a checkpoint, or a [SyncFailure] while
+// reading entries, back off with full jitter, up to the configured
+// [WithMaxBackoff], instead of retrying at the fixed poll interval.
+func (m *Monitor) Run(ctx context.Context) error {
+	var failures int
+	for {
+		ok, err := m.poll(ctx)
+		if err != nil {
+			return err
+		}
+
+		wait := m.interval
+		if !ok {
+			failures++
+			wait = fullJitterBackoff(failures, m.maxBackoff)
+		} else {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// poll fetches and processes a single checkpoint. It returns ok == false if
+// the CheckpointFetcher itself failed or reading entries failed for
+// operational reasons ([SyncFailure]), so Run knows to back off, and a
+// non-nil error only for failures that should stop the Monitor entirely.
+func (m *Monitor) poll(ctx context.Context) (ok bool, err error) {
+	tree, err := m.fetch(ctx)
+	if err != nil {
+		m.emit(ctx, SecurityEvent{Kind: BadCheckpoint, Err: err})
+		return false, nil
+	}
+
+	if r, ok := m.sink.(treeStateRecorder); ok {
+		if err := r.RecordTreeState(ctx, tree, time.Now()); err != nil {
+			return true, fmt.Errorf("recording tree state at size %d: %w", tree.N, err)
+		}
+	}
+
+	for i, entry := range m.client.Entries(ctx, tree, m.pos) {
+		rh := tlog.RecordHash(entry)
+		if err := m.sink.Store(ctx, i, entry, rh); err != nil {
+			return true, fmt.Errorf("storing entry %d: %w", i, err)
+		}
+		m.pos = i + 1
+	}
+
+	if err := m.client.Err(); err != nil {
+		kind := SyncFailure
+		switch {
+		case errors.Is(err, torchwood.ErrLogFork):
+			kind = ConsistencyFailure
+		case errors.Is(err, torchwood.ErrInclusionMismatch):
+			kind = InclusionFailure
+		}
+		m.emit(ctx, SecurityEvent{Kind: kind, Err: err})
+		if kind == SyncFailure {
+			// Unlike a fork or a corrupted entry, a plain operational
+			// failure may well clear up if we wait, so back off the same
+			// way we do for a failed checkpoint fetch instead of
+			// hammering the log again next interval.
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// fullJitterBackoff returns a randomized delay before retry attempt n (1 or
+// more), uniformly distributed between zero and min(2^n seconds, maxDelay).
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+func fullJitterBackoff(attempt int, maxDelay time.Duration) time.Duration {
+	// 2^n seconds no longer fits in a time.Duration past n = 33.
+	backoffCap := time.Duration(math.MaxInt64)
+	if attempt < 34 {
+		backoffCap = time.Duration(1<<uint(attempt)) * time.Second
+	}
+	if maxDelay > 0 && backoffCap > maxDelay {
+		backoffCap = maxDelay
+	}
+	if backoffCap <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(backoffCap)))
+}
+
+func (m *Monitor) emit(ctx context.Context, e SecurityEvent) {
+	select {
+	case m.events <- e:
+	case <-ctx.Done():
+	}
+}
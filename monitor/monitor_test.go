@@ -0,0 +1,345 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/mod/sumdb/tlog"
+
+	"filippo.io/torchwood"
+)
+
+// memTiles and newTestLog mirror the in-memory log fixture used by
+// torchwood's own tests; they're rebuilt here because that fixture is
+// unexported in that package.
+type memTiles struct {
+	data        []byte
+	dataWidth   int
+	levelHashes map[int][]tlog.Hash
+}
+
+func (m *memTiles) ReadTiles(ctx context.Context, tiles []tlog.Tile) ([][]byte, error) {
+	out := make([][]byte, len(tiles))
+	for i, t := range tiles {
+		if t.L == -1 {
+			if t.N != 0 || t.W != m.dataWidth {
+				return nil, fmt.Errorf("memTiles: unexpected data tile request %+v", t)
+			}
+			out[i] = m.data
+			continue
+		}
+		// Hash tile L holds the tree's hashes at level L*H.
+		hashes := m.levelHashes[t.L*t.H]
+		if t.N != 0 || t.W > len(hashes) {
+			return nil, fmt.Errorf("memTiles: unexpected hash tile request %+v", t)
+		}
+		var buf bytes.Buffer
+		for _, h := range hashes[:t.W] {
+			buf.Write(h[:])
+		}
+		out[i] = buf.Bytes()
+	}
+	return out, nil
+}
+
+func (m *memTiles) SaveTiles(tiles []tlog.Tile, data [][]byte) {}
+
+// erroringTiles always fails to read, standing in for a struggling tile
+// server that exhausted its retries.
+type erroringTiles struct{}
+
+func (erroringTiles) ReadTiles(ctx context.Context, tiles []tlog.Tile) ([][]byte, error) {
+	return nil, errors.New("simulated network error")
+}
+
+func (erroringTiles) SaveTiles(tiles []tlog.Tile, data [][]byte) {}
+
+type hashReaderFunc func([]int64) ([]tlog.Hash, error)
+
+func (f hashReaderFunc) ReadHashes(indexes []int64) ([]tlog.Hash, error) { return f(indexes) }
+
+// newTestLog builds an in-memory log of n leaves, in the
+// go.dev/design/25530-sumdb entry format, and returns its [tlog.Tree]
+// alongside the [memTiles] that serves it.
+func newTestLog(t *testing.T, n int) (tlog.Tree, *memTiles) {
+	t.Helper()
+
+	entries := make([]string, n)
+	for i := range entries {
+		entries[i] = fmt.Sprintf("entry %d", i)
+	}
+	var buf bytes.Buffer
+	leafHashes := make([]tlog.Hash, n)
+	for i, e := range entries {
+		if i < n-1 {
+			buf.WriteString(e)
+			buf.WriteString("\n\n")
+			leafHashes[i] = tlog.RecordHash([]byte(e + "\n"))
+		} else {
+			buf.WriteString(e)
+			leafHashes[i] = tlog.RecordHash([]byte(e))
+		}
+	}
+
+	store := map[int64]tlog.Hash{}
+	levelHashes := map[int][]tlog.Hash{}
+	type node struct {
+		level int
+		n     int64
+		hash  tlog.Hash
+	}
+	var stack []node
+	for i, h := range leafHashes {
+		cur := node{level: 0, n: int64(i), hash: h}
+		store[tlog.StoredHashIndex(cur.level, cur.n)] = cur.hash
+		levelHashes[cur.level] = append(levelHashes[cur.level], cur.hash)
+		for len(stack) > 0 && stack[len(stack)-1].level == cur.level {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			cur = node{level: cur.level + 1, n: top.n / 2, hash: tlog.NodeHash(top.hash, cur.hash)}
+			store[tlog.StoredHashIndex(cur.level, cur.n)] = cur.hash
+			levelHashes[cur.level] = append(levelHashes[cur.level], cur.hash)
+		}
+		stack = append(stack, cur)
+	}
+
+	hr := hashReaderFunc(func(indexes []int64) ([]tlog.Hash, error) {
+		out := make([]tlog.Hash, len(indexes))
+		for i, idx := range indexes {
+			h, ok := store[idx]
+			if !ok {
+				return nil, fmt.Errorf("newTestLog: stored hash %d not available", idx)
+			}
+			out[i] = h
+		}
+		return out, nil
+	})
+	root, err := tlog.TreeHash(int64(n), hr)
+	if err != nil {
+		t.Fatalf("computing test tree hash: %v", err)
+	}
+
+	return tlog.Tree{N: int64(n), Hash: root}, &memTiles{data: buf.Bytes(), dataWidth: n, levelHashes: levelHashes}
+}
+
+// memCheckpointStore is an in-memory [torchwood.CheckpointStore] for
+// tests.
+type memCheckpointStore struct {
+	tree tlog.Tree
+}
+
+func (s *memCheckpointStore) ReadLatest(ctx context.Context) (tlog.Tree, error) {
+	return s.tree, nil
+}
+
+func (s *memCheckpointStore) WriteLatest(ctx context.Context, tree tlog.Tree) error {
+	s.tree = tree
+	return nil
+}
+
+// fakeSink is an [EntrySink] that records every entry it's given.
+type fakeSink struct {
+	entries map[int64][]byte
+}
+
+func newFakeSink() *fakeSink {
+	return &fakeSink{entries: make(map[int64][]byte)}
+}
+
+func (s *fakeSink) Store(ctx context.Context, index int64, entry []byte, rh tlog.Hash) error {
+	s.entries[index] = append([]byte(nil), entry...)
+	return nil
+}
+
+func newVerifyingClient(t *testing.T, tr torchwood.TileReaderWithContext, store torchwood.CheckpointStore) *torchwood.VerifyingClient {
+	t.Helper()
+	vc, err := torchwood.NewVerifyingClient(tr, store, torchwood.WithSumDBEntries())
+	if err != nil {
+		t.Fatalf("NewVerifyingClient: %v", err)
+	}
+	return vc
+}
+
+func mustReceiveEvent(t *testing.T, m *Monitor) SecurityEvent {
+	t.Helper()
+	select {
+	case ev := <-m.Events():
+		return ev
+	default:
+		t.Fatalf("expected a SecurityEvent, got none")
+		return SecurityEvent{}
+	}
+}
+
+func TestMonitorPollTailsNormally(t *testing.T) {
+	tree, mt := newTestLog(t, 6)
+	vc := newVerifyingClient(t, mt, &memCheckpointStore{})
+	sink := newFakeSink()
+	m := NewMonitor(func(ctx context.Context) (tlog.Tree, error) { return tree, nil }, vc, sink)
+
+	ok, err := m.poll(context.Background())
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if !ok {
+		t.Fatalf("poll ok = false, want true")
+	}
+	select {
+	case ev := <-m.Events():
+		t.Fatalf("unexpected event: %+v", ev)
+	default:
+	}
+	if len(sink.entries) != 6 {
+		t.Fatalf("got %d entries, want 6", len(sink.entries))
+	}
+	for i := 0; i < 6; i++ {
+		want := fmt.Sprintf("entry %d", i)
+		if i < 5 {
+			// WithSumDBEntries keeps one newline of the separator on
+			// every entry but the last.
+			want += "\n"
+		}
+		if string(sink.entries[int64(i)]) != want {
+			t.Fatalf("entry %d = %q, want %q", i, sink.entries[int64(i)], want)
+		}
+	}
+}
+
+// TestMonitorPollReportsForkAsConsistencyFailure simulates a log that
+// serves a different root hash for a tree of the same size it already
+// vouched for, and checks that poll reports it as a ConsistencyFailure
+// without backing off, since retrying won't resolve a fork.
+func TestMonitorPollReportsForkAsConsistencyFailure(t *testing.T) {
+	tree, mt := newTestLog(t, 4)
+	vc := newVerifyingClient(t, mt, &memCheckpointStore{})
+	sink := newFakeSink()
+	m := NewMonitor(func(ctx context.Context) (tlog.Tree, error) { return tree, nil }, vc, sink)
+
+	if ok, err := m.poll(context.Background()); err != nil || !ok {
+		t.Fatalf("first poll: ok=%v err=%v", ok, err)
+	}
+
+	forked := tree
+	forked.Hash[0] ^= 0xff
+	m.fetch = func(ctx context.Context) (tlog.Tree, error) { return forked, nil }
+
+	ok, err := m.poll(context.Background())
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if !ok {
+		t.Fatalf("poll ok = false, want true (a fork is not an operational failure)")
+	}
+	ev := mustReceiveEvent(t, m)
+	if ev.Kind != ConsistencyFailure {
+		t.Fatalf("event kind = %v, want ConsistencyFailure", ev.Kind)
+	}
+	if !errors.Is(ev.Err, torchwood.ErrLogFork) {
+		t.Fatalf("event err = %v, want ErrLogFork", ev.Err)
+	}
+}
+
+// TestMonitorPollReportsCorruptedEntryAsInclusionFailure tampers with a
+// data tile's bytes, without touching the tree it's meant to match, and
+// checks that poll reports the mismatch as an InclusionFailure without
+// backing off.
+func TestMonitorPollReportsCorruptedEntryAsInclusionFailure(t *testing.T) {
+	tree, mt := newTestLog(t, 4)
+
+	corrupted := append([]byte(nil), mt.data...)
+	target, replacement := []byte("entry 2"), []byte("ENTRY 2")
+	idx := bytes.Index(corrupted, target)
+	if idx < 0 {
+		t.Fatalf("test entry %q not found in tile data", target)
+	}
+	copy(corrupted[idx:], replacement)
+	tampered := &memTiles{data: corrupted, dataWidth: mt.dataWidth, levelHashes: mt.levelHashes}
+
+	vc := newVerifyingClient(t, tampered, &memCheckpointStore{})
+	sink := newFakeSink()
+	m := NewMonitor(func(ctx context.Context) (tlog.Tree, error) { return tree, nil }, vc, sink)
+
+	ok, err := m.poll(context.Background())
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if !ok {
+		t.Fatalf("poll ok = false, want true (an inclusion failure is not operational)")
+	}
+	ev := mustReceiveEvent(t, m)
+	if ev.Kind != InclusionFailure {
+		t.Fatalf("event kind = %v, want InclusionFailure", ev.Kind)
+	}
+	if !errors.Is(ev.Err, torchwood.ErrInclusionMismatch) {
+		t.Fatalf("event err = %v, want ErrInclusionMismatch", ev.Err)
+	}
+}
+
+// TestMonitorPollBacksOffOnCheckpointFetchFailure checks that a failed
+// checkpoint fetch is reported as BadCheckpoint and tells Run to back
+// off.
+func TestMonitorPollBacksOffOnCheckpointFetchFailure(t *testing.T) {
+	vc := newVerifyingClient(t, erroringTiles{}, &memCheckpointStore{})
+	sink := newFakeSink()
+	fetchErr := errors.New("simulated fetch failure")
+	m := NewMonitor(func(ctx context.Context) (tlog.Tree, error) { return tlog.Tree{}, fetchErr }, vc, sink)
+
+	ok, err := m.poll(context.Background())
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if ok {
+		t.Fatalf("poll ok = true, want false (a failed checkpoint fetch should back off)")
+	}
+	ev := mustReceiveEvent(t, m)
+	if ev.Kind != BadCheckpoint {
+		t.Fatalf("event kind = %v, want BadCheckpoint", ev.Kind)
+	}
+}
+
+// TestMonitorPollBacksOffOnSyncFailure checks that an operational
+// failure while reading entries — as opposed to a fork or a corrupted
+// entry — is reported as SyncFailure and also tells Run to back off.
+func TestMonitorPollBacksOffOnSyncFailure(t *testing.T) {
+	tree, _ := newTestLog(t, 4)
+	vc := newVerifyingClient(t, erroringTiles{}, &memCheckpointStore{})
+	sink := newFakeSink()
+	m := NewMonitor(func(ctx context.Context) (tlog.Tree, error) { return tree, nil }, vc, sink)
+
+	ok, err := m.poll(context.Background())
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if ok {
+		t.Fatalf("poll ok = true, want false (an operational read failure should back off)")
+	}
+	ev := mustReceiveEvent(t, m)
+	if ev.Kind != SyncFailure {
+		t.Fatalf("event kind = %v, want SyncFailure", ev.Kind)
+	}
+}
+
+// TestMonitorRunTailsUntilCanceled exercises the Run loop itself, rather
+// than poll directly, checking that it streams every entry into the
+// sink and returns ctx.Err() once ctx is canceled.
+func TestMonitorRunTailsUntilCanceled(t *testing.T) {
+	tree, mt := newTestLog(t, 5)
+	vc := newVerifyingClient(t, mt, &memCheckpointStore{})
+	sink := newFakeSink()
+	m := NewMonitor(func(ctx context.Context) (tlog.Tree, error) { return tree, nil }, vc, sink,
+		WithPollInterval(time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := m.Run(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run err = %v, want context.DeadlineExceeded", err)
+	}
+	if len(sink.entries) != 5 {
+		t.Fatalf("got %d entries, want 5", len(sink.entries))
+	}
+}
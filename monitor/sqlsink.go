@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+// SQLSink is a reference [EntrySink] that stores observed entries and tree
+// state in a SQL database, modeled on the gosumdbaudit monitor's schema.
+// It expects the caller to have already created the following tables:
+//
+//	CREATE TABLE entries (
+//		idx         BIGINT PRIMARY KEY,
+//		entry       BLOB NOT NULL,
+//		record_hash BLOB NOT NULL
+//	);
+//
+//	CREATE TABLE tree_state (
+//		size        BIGINT PRIMARY KEY,
+//		root_hash   BLOB NOT NULL,
+//		observed_at TIMESTAMP NOT NULL
+//	);
+//
+// one tree_state row per observed checkpoint.
+type SQLSink struct {
+	db *sql.DB
+}
+
+// NewSQLSink creates a new [SQLSink] backed by db.
+func NewSQLSink(db *sql.DB) *SQLSink {
+	return &SQLSink{db: db}
+}
+
+// Store implements [EntrySink]. It is safe to call concurrently, and
+// tolerates being called more than once for the same index.
+func (s *SQLSink) Store(ctx context.Context, index int64, entry []byte, rh tlog.Hash) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO entries (idx, entry, record_hash) VALUES (?, ?, ?)
+		ON CONFLICT (idx) DO NOTHING`,
+		index, entry, rh[:])
+	if err != nil {
+		return fmt.Errorf("storing entry %d: %w", index, err)
+	}
+	return nil
+}
+
+// RecordTreeState records an observed checkpoint in the tree_state table.
+// A [Monitor] using this sink calls it on every poll, for each tree its
+// [CheckpointFetcher] returns. If a row for the same size already exists,
+// it is kept and the new one is not recorded.
+func (s *SQLSink) RecordTreeState(ctx context.Context, tree tlog.Tree, observedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO tree_state (size, root_hash, observed_at) VALUES (?, ?, ?)
+		ON CONFLICT (size) DO NOTHING`,
+		tree.N, tree.Hash[:], observedAt)
+	if err != nil {
+		return fmt.Errorf("recording tree state at size %d: %w", tree.N, err)
+	}
+	return nil
+}
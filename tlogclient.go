@@ -8,25 +8,36 @@ import (
 	"io"
 	"iter"
 	"log/slog"
-	"math"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/mod/sumdb/tlog"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
 )
 
+// ErrInclusionMismatch is wrapped by the error returned from [Client.Entries],
+// [Client.Lookup], and [Client.LookupAll] when an entry's record hash does
+// not match the hash recorded for it in the tree, i.e. the log served a
+// corrupted or tampered entry.
+var ErrInclusionMismatch = errors.New("torchwood: entry hash does not match tree")
+
 // Client is a tlog client that fetches and authenticates tiles, and exposes log
 // entries as a Go iterator.
 type Client struct {
-	tr      TileReaderWithContext
-	cut     func([]byte) ([]byte, tlog.Hash, []byte, error)
-	timeout time.Duration
-	err     error
+	tr       TileReaderWithContext
+	cut      func([]byte) ([]byte, tlog.Hash, []byte, error)
+	timeout  time.Duration
+	err      error
+	resolver IndexResolver
+	lookups  parCache
 }
 
 // NewClient creates a new [Client] that fetches tiles using the given
@@ -87,6 +98,22 @@ func WithSumDBEntries() ClientOption {
 	}
 }
 
+// IndexResolver maps a record key, such as the hash of a lookup key, to its
+// leaf index in the log. Callers who maintain their own key-to-index
+// mapping, for example in a side table alongside a monitor's entry store,
+// can plug it in with [WithIndexResolver] to use [Client.LookupByKey].
+type IndexResolver interface {
+	ResolveIndex(ctx context.Context, key []byte) (index int64, err error)
+}
+
+// WithIndexResolver configures the [IndexResolver] used by
+// [Client.LookupByKey].
+func WithIndexResolver(r IndexResolver) ClientOption {
+	return func(c *Client) {
+		c.resolver = r
+	}
+}
+
 // Err returns the error encountered by the latest [Client.Entries] call.
 func (c *Client) Err() error {
 	return c.err
@@ -150,23 +177,48 @@ func (c *Client) Entries(ctx context.Context, tree tlog.Tree, start int64) iter.
 				return
 			}
 
-			// TODO: hash data tile directly against level 8 hash.
-			indexes := make([]int64, 0, TileWidth*len(tiles))
-			for _, t := range tiles {
-				for i := range t.W {
-					indexes = append(indexes, tlog.StoredHashIndex(0, t.N*TileWidth+int64(i)))
+			// For full tiles, verify the tile as a whole against a single
+			// hash at level TileHeight, rather than walking the tree once
+			// per leaf with ReadHashes. Only the final, partial tile (if
+			// any) still needs one hash per leaf, since it has no single
+			// node at level TileHeight covering exactly its W leaves.
+			fullTileHashPos := make(map[int]int, len(tiles))
+			fullIndexes := make([]int64, 0, len(tiles))
+			partialHashPos := make(map[int]int, len(tiles))
+			partialIndexes := make([]int64, 0, TileWidth)
+			for ti, t := range tiles {
+				if t.W == TileWidth {
+					fullTileHashPos[ti] = len(fullIndexes)
+					fullIndexes = append(fullIndexes, tlog.StoredHashIndex(TileHeight, t.N))
+				} else {
+					partialHashPos[ti] = len(partialIndexes)
+					for i := range t.W {
+						partialIndexes = append(partialIndexes, tlog.StoredHashIndex(0, t.N*TileWidth+int64(i)))
+					}
 				}
 			}
-			hashes, err := TileHashReaderWithContext(ctx, tree, c.tr).ReadHashes(indexes)
+			hashReader := TileHashReaderWithContext(ctx, tree, c.tr)
+			fullHashes, err := hashReader.ReadHashes(fullIndexes)
 			if err != nil {
 				c.err = err
 				return
 			}
+			var partialHashes []tlog.Hash
+			if len(partialIndexes) > 0 {
+				partialHashes, err = hashReader.ReadHashes(partialIndexes)
+				if err != nil {
+					c.err = err
+					return
+				}
+			}
 
 			for ti, t := range tiles {
 				tileStart := t.N * TileWidth
 				tileEnd := tileStart + int64(t.W)
 				data := tdata[ti]
+
+				entries := make([][]byte, 0, t.W)
+				recordHashes := make([]tlog.Hash, 0, t.W)
 				for i := tileStart; i < tileEnd; i++ {
 					if err := ctx.Err(); err != nil {
 						c.err = err
@@ -185,11 +237,36 @@ func (c *Client) Entries(ctx context.Context, tree tlog.Tree, start int64) iter.
 					}
 					data = rest
 
-					if rh != hashes[i-base] {
-						c.err = fmt.Errorf("hash mismatch for entry %d", i)
+					entries = append(entries, entry)
+					recordHashes = append(recordHashes, rh)
+				}
+				if len(data) != 0 {
+					c.err = fmt.Errorf("unexpected leftover data in tile %d", t.N)
+					return
+				}
+
+				if t.W == TileWidth {
+					if got, want := hashTile(recordHashes), fullHashes[fullTileHashPos[ti]]; got != want {
+						c.err = fmt.Errorf("%w: tile %d", ErrInclusionMismatch, t.N)
+						return
+					}
+				} else {
+					pos := partialHashPos[ti]
+					for j, rh := range recordHashes {
+						if rh != partialHashes[pos+j] {
+							c.err = fmt.Errorf("%w: entry %d", ErrInclusionMismatch, tileStart+int64(j))
+							return
+						}
+					}
+				}
+
+				for j, entry := range entries {
+					if err := ctx.Err(); err != nil {
+						c.err = err
 						return
 					}
 
+					i := tileStart + int64(j)
 					if i < start {
 						continue
 					}
@@ -200,10 +277,6 @@ func (c *Client) Entries(ctx context.Context, tree tlog.Tree, start int64) iter.
 					ctx, cancel = context.WithTimeout(mainCtx, c.timeout)
 					_ = cancel // https://go.dev/issue/25720
 				}
-				if len(data) != 0 {
-					c.err = fmt.Errorf("unexpected leftover data in tile %d", t.N)
-					return
-				}
 				start = tileEnd
 			}
 
@@ -285,6 +358,18 @@ func (c *edgeMemoryCache) SaveTiles(tiles []tlog.Tile, data [][]byte) {
 	}
 }
 
+// hashTile computes the level-TileHeight hash of a full data tile by
+// folding its leaf record hashes pairwise with [tlog.NodeHash], the same
+// recursive combination [tlog.HashFromTile] computes from a stored hash
+// tile. hashes must have exactly TileWidth elements.
+func hashTile(hashes []tlog.Hash) tlog.Hash {
+	if len(hashes) == 1 {
+		return hashes[0]
+	}
+	mid := len(hashes) / 2
+	return tlog.NodeHash(hashTile(hashes[:mid]), hashTile(hashes[mid:]))
+}
+
 func tileLess(a, b tlog.Tile) bool {
 	// A zero tile is always less than any other tile.
 	if a == (tlog.Tile{}) {
@@ -299,14 +384,35 @@ func tileLess(a, b tlog.Tile) bool {
 	return a.N < b.N || (a.N == b.N && a.W < b.W)
 }
 
+// Metrics receives structured observations about tile fetches performed by
+// a [TileFetcher]. A caller can wire it to Prometheus or another metrics
+// system.
+type Metrics interface {
+	ObserveFetch(path string, status int, bytes int, dur time.Duration)
+}
+
 // TileFetcher is a [TileReaderWithContext] that fetches tiles from a remote server.
 type TileFetcher struct {
-	base     string
-	hc       *http.Client
-	ua       string
-	log      *slog.Logger
-	limit    int
-	tilePath func(tlog.Tile) string
+	base          string
+	hc            *http.Client
+	ua            string
+	log           *slog.Logger
+	tilePath      func(tlog.Tile) string
+	limiter       *rate.Limiter
+	perHostLimit  int
+	maxRetryDelay time.Duration
+	metrics       Metrics
+
+	sems sync.Map // host string -> chan struct{}
+
+	partialMu sync.Mutex
+	partial   map[int64]cachedPartialTile // tile N -> cached data and ETag for its latest known width
+}
+
+type cachedPartialTile struct {
+	width int
+	etag  string
+	data  []byte
 }
 
 // NewTileFetcher creates a new [TileFetcher] that fetches tiles from the given
@@ -316,7 +422,7 @@ func NewTileFetcher(base string, opts ...TileFetcherOption) (*TileFetcher, error
 		base += "/"
 	}
 
-	tf := &TileFetcher{base: base}
+	tf := &TileFetcher{base: base, partial: make(map[int64]cachedPartialTile)}
 	for _, opt := range opts {
 		opt(tf)
 	}
@@ -337,6 +443,15 @@ func NewTileFetcher(base string, opts ...TileFetcherOption) (*TileFetcher, error
 	if tf.log == nil {
 		tf.log = slog.New(slogDiscardHandler{})
 	}
+	if tf.limiter == nil {
+		tf.limiter = rate.NewLimiter(rate.Inf, 0)
+	}
+	if tf.perHostLimit == 0 {
+		tf.perHostLimit = http.DefaultMaxIdleConnsPerHost
+		if t, ok := tf.hc.Transport.(*http.Transport); ok && t.MaxIdleConnsPerHost > 0 {
+			tf.perHostLimit = t.MaxIdleConnsPerHost
+		}
+	}
 
 	return tf, nil
 }
@@ -371,10 +486,36 @@ func WithUserAgent(ua string) TileFetcherOption {
 }
 
 // WithConcurrencyLimit configures the maximum number of concurrent requests
-// made by the TileFetcher. By default, there is no limit.
+// the TileFetcher makes to a single host. By default, it's taken from the
+// HTTP client's Transport.MaxIdleConnsPerHost.
 func WithConcurrencyLimit(limit int) TileFetcherOption {
 	return func(f *TileFetcher) {
-		f.limit = limit
+		f.perHostLimit = limit
+	}
+}
+
+// WithRateLimit configures a cap on the rate of requests the TileFetcher
+// issues, as requests per second with the given burst size. By default,
+// requests are not rate limited.
+func WithRateLimit(requestsPerSecond float64, burst int) TileFetcherOption {
+	return func(f *TileFetcher) {
+		f.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+	}
+}
+
+// WithMaxRetryDelay caps the delay the TileFetcher will wait between retries,
+// including when honoring a Retry-After response header. By default, there
+// is no cap.
+func WithMaxRetryDelay(d time.Duration) TileFetcherOption {
+	return func(f *TileFetcher) {
+		f.maxRetryDelay = d
+	}
+}
+
+// WithMetrics configures a [Metrics] to report tile fetch observations to.
+func WithMetrics(m Metrics) TileFetcherOption {
+	return func(f *TileFetcher) {
+		f.metrics = m
 	}
 }
 
@@ -390,70 +531,174 @@ func WithTilePath(tilePath func(tlog.Tile) string) TileFetcherOption {
 }
 
 // ReadTiles implements [TileReaderWithContext]. It retries 429 and 5xx
-// responses, and network errors.
+// responses, and network errors, honoring both the Retry-After header and
+// WithMaxRetryDelay.
 func (f *TileFetcher) ReadTiles(ctx context.Context, tiles []tlog.Tile) (data [][]byte, err error) {
 	data = make([][]byte, len(tiles))
 	errGroup, ctx := errgroup.WithContext(ctx)
-	if f.limit > 0 {
-		errGroup.SetLimit(f.limit)
-	}
 	for i, t := range tiles {
 		if t.H != TileHeight {
 			return nil, fmt.Errorf("unexpected tile height %d", t.H)
 		}
 		errGroup.Go(func() error {
-			path := f.tilePath(t)
-			req, err := http.NewRequestWithContext(ctx, "GET", f.base+path, nil)
-			if err != nil {
-				return fmt.Errorf("%s: failed to create request: %w", path, err)
+			sem := f.hostSemaphore()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
 			}
-			var errs error
-			var retryAfter time.Time
-			for j := range 5 {
-				if j > 0 {
-					// Wait 1s, 5s, 25s, or 125s before retrying.
-					pause := time.Duration(math.Pow(5, float64(j-1))) * time.Second
-					if !retryAfter.IsZero() {
-						pause = time.Until(retryAfter)
-						retryAfter = time.Time{}
-					}
-					f.log.InfoContext(ctx, "retrying tile fetch", "path", path,
-						"pause", pause, "errs", errs, "retry", j)
-					select {
-					case <-ctx.Done():
-						return ctx.Err()
-					case <-time.After(pause):
-					}
-				}
-				req.Header.Set("User-Agent", f.ua)
-				resp, err := f.hc.Do(req)
-				if err != nil {
-					errs = errors.Join(errs, err)
-					continue
-				}
-				defer resp.Body.Close()
-				switch {
-				case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
-					retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
-					errs = errors.Join(errs, fmt.Errorf("unexpected status code %d", resp.StatusCode))
-					continue
-				case resp.StatusCode != http.StatusOK:
-					return fmt.Errorf("%s: unexpected status code %d", path, resp.StatusCode)
-				}
-				data[i], err = io.ReadAll(resp.Body)
-				if err != nil {
-					errs = errors.Join(errs, err)
-					continue
-				}
-				f.log.InfoContext(ctx, "fetched tile", "path", path, "size", len(data[i]))
-				return nil
+			defer func() { <-sem }()
+
+			d, err := f.fetchTile(ctx, t)
+			if err != nil {
+				return err
 			}
-			return fmt.Errorf("%s: %w", path, errs)
+			data[i] = d
+			return nil
 		})
 	}
 	return data, errGroup.Wait()
 }
 
+// hostSemaphore returns the channel used to cap concurrent requests to the
+// TileFetcher's base host at f.perHostLimit.
+func (f *TileFetcher) hostSemaphore() chan struct{} {
+	host := f.base
+	if u, err := url.Parse(f.base); err == nil {
+		host = u.Host
+	}
+	if v, ok := f.sems.Load(host); ok {
+		return v.(chan struct{})
+	}
+	sem := make(chan struct{}, f.perHostLimit)
+	actual, _ := f.sems.LoadOrStore(host, sem)
+	return actual.(chan struct{})
+}
+
+// fetchTile fetches a single tile, retrying on 429 and 5xx responses and
+// network errors with full-jitter backoff, and using a cached ETag for
+// partial tiles so repeated polls that observe no growth don't redownload
+// bytes they already have.
+//
+// The cache holds at most one entry per tile number, keyed by t.N rather
+// than the request path: the c2sp.org/tlog-tiles partial-tile path encodes
+// the tile's width, which changes every time the log grows, so keying by
+// path would otherwise accumulate one stale entry per width ever observed
+// for the lifetime of the TileFetcher.
+func (f *TileFetcher) fetchTile(ctx context.Context, t tlog.Tile) ([]byte, error) {
+	path := f.tilePath(t)
+	partial := t.W < TileWidth
+
+	var cached cachedPartialTile
+	if partial {
+		f.partialMu.Lock()
+		cached = f.partial[t.N]
+		f.partialMu.Unlock()
+		if cached.width != t.W {
+			// The tile grew (or shrank, which shouldn't happen) since we
+			// last cached it: the ETag was for a different resource, so it
+			// can't be used to validate this request.
+			cached = cachedPartialTile{}
+		}
+	}
+
+	var errs error
+	var retryAfter time.Time
+	for attempt := range 5 {
+		if attempt > 0 {
+			pause := fullJitterBackoff(attempt, f.maxRetryDelay)
+			if !retryAfter.IsZero() {
+				pause = time.Until(retryAfter)
+				retryAfter = time.Time{}
+				if f.maxRetryDelay > 0 && pause > f.maxRetryDelay {
+					pause = f.maxRetryDelay
+				}
+			}
+			f.log.InfoContext(ctx, "retrying tile fetch", "path", path,
+				"pause", pause, "errs", errs, "retry", attempt)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(pause):
+			}
+		}
+
+		if err := f.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", f.base+path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to create request: %w", path, err)
+		}
+		req.Header.Set("User-Agent", f.ua)
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+
+		start := time.Now()
+		resp, err := f.hc.Do(req)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotModified && cached.data != nil {
+			f.observe(path, resp.StatusCode, 0, time.Since(start))
+			f.log.InfoContext(ctx, "tile not modified", "path", path)
+			return cached.data, nil
+		}
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			errs = errors.Join(errs, fmt.Errorf("unexpected status code %d", resp.StatusCode))
+			f.observe(path, resp.StatusCode, 0, time.Since(start))
+			continue
+		case resp.StatusCode != http.StatusOK:
+			return nil, fmt.Errorf("%s: unexpected status code %d", path, resp.StatusCode)
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		f.observe(path, resp.StatusCode, len(data), time.Since(start))
+		f.log.InfoContext(ctx, "fetched tile", "path", path, "size", len(data))
+
+		if partial {
+			if etag := resp.Header.Get("ETag"); etag != "" {
+				f.partialMu.Lock()
+				f.partial[t.N] = cachedPartialTile{width: t.W, etag: etag, data: data}
+				f.partialMu.Unlock()
+			}
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("%s: %w", path, errs)
+}
+
+func (f *TileFetcher) observe(path string, status, bytes int, dur time.Duration) {
+	if f.metrics != nil {
+		f.metrics.ObserveFetch(path, status, bytes, dur)
+	}
+}
+
+// fullJitterBackoff returns a randomized delay before retry attempt n (1 or
+// more), uniformly distributed between zero and min(2^n seconds, maxDelay).
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+func fullJitterBackoff(attempt int, maxDelay time.Duration) time.Duration {
+	backoffCap := time.Duration(1<<uint(attempt)) * time.Second
+	if maxDelay > 0 && backoffCap > maxDelay {
+		backoffCap = maxDelay
+	}
+	if backoffCap <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(backoffCap)))
+}
+
 // parseRetryAfter parses the Retry-After header value. It returns the time
 // to wait before retrying the request. If the header is not present or
 // invalid, it returns zero.
@@ -0,0 +1,81 @@
+package torchwood
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestClientEntriesVerifiesGoodTile(t *testing.T) {
+	n := int(TileWidth)
+	tree, mt := newTestLog(t, "main", n)
+
+	c, err := NewClient(mt, WithSumDBEntries())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	var got []string
+	for i, entry := range c.Entries(context.Background(), tree, 0) {
+		if i != int64(len(got)) {
+			t.Fatalf("entry %d yielded out of order at position %d", i, len(got))
+		}
+		got = append(got, string(entry))
+	}
+	if err := c.Err(); err != nil {
+		t.Fatalf("Entries: %v", err)
+	}
+	if len(got) != n {
+		t.Fatalf("got %d entries, want %d", len(got), n)
+	}
+}
+
+// TestClientEntriesDetectsCorruptedTile tampers with a full data tile's
+// bytes, without touching the tree it's meant to match, and verifies
+// that [Client.Entries] refuses to yield the corrupted entry and reports
+// [ErrInclusionMismatch] instead of silently trusting what the log
+// served.
+func TestClientEntriesDetectsCorruptedTile(t *testing.T) {
+	n := int(TileWidth)
+	tree, mt := newTestLog(t, "main", n)
+
+	corrupted := append([]byte(nil), mt.data...)
+	// "entry 5" -> "ENTRY 5": same length, so the tile still cuts cleanly
+	// into the same number of entries, only the 6th one now hashes
+	// differently than what's recorded in the tree.
+	target := []byte("entry 5")
+	replacement := []byte("ENTRY 5")
+	idx := indexOf(corrupted, target)
+	if idx < 0 {
+		t.Fatalf("test entry %q not found in tile data", target)
+	}
+	copy(corrupted[idx:], replacement)
+
+	tampered := &memTiles{data: corrupted, dataWidth: mt.dataWidth, levelHashes: mt.levelHashes}
+	c, err := NewClient(tampered, WithSumDBEntries())
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	for range c.Entries(context.Background(), tree, 0) {
+	}
+	if err := c.Err(); !errors.Is(err, ErrInclusionMismatch) {
+		t.Fatalf("Entries err = %v, want ErrInclusionMismatch", err)
+	}
+}
+
+func indexOf(haystack, needle []byte) int {
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,168 @@
+package torchwood
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+// ErrLogFork is the error wrapped by [VerifyingClient.Err] when a
+// consistency proof fails to verify, indicating that the log has forked: the
+// new tree is not an append-only extension of the last trusted tree.
+var ErrLogFork = errors.New("torchwood: consistency proof failed, log may have forked")
+
+// CheckpointStore persists the latest trusted tree across invocations of a
+// [VerifyingClient], mirroring the role of the sumdb Client's ReadConfig and
+// WriteConfig for the "NAME/latest" file.
+type CheckpointStore interface {
+	// ReadLatest returns the last trusted tree, or the zero [tlog.Tree] if
+	// none has been stored yet.
+	ReadLatest(ctx context.Context) (tlog.Tree, error)
+
+	// WriteLatest stores tree as the new latest trusted tree, replacing
+	// whatever was stored before.
+	WriteLatest(ctx context.Context, tree tlog.Tree) error
+}
+
+// VerifyingClient wraps a [Client] to additionally enforce that every tree
+// passed to Entries is a verified append-only extension of the last tree it
+// was given, refusing to advance its stored checkpoint otherwise. This is
+// the same "detect log fork" invariant the Go sumdb Client enforces when
+// merging a newly fetched signed tree with its saved latest.
+type VerifyingClient struct {
+	c     *Client
+	store CheckpointStore
+	err   error
+}
+
+// NewVerifyingClient creates a new [VerifyingClient] that fetches tiles
+// using tr and persists its trusted tree in store.
+func NewVerifyingClient(tr TileReaderWithContext, store CheckpointStore, opts ...ClientOption) (*VerifyingClient, error) {
+	c, err := NewClient(tr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifyingClient{c: c, store: store}, nil
+}
+
+// Err returns the error encountered by the latest [VerifyingClient.Entries]
+// call. It wraps [ErrLogFork] if the stored tree could not be advanced to
+// newTree because the consistency proof between them failed.
+func (vc *VerifyingClient) Err() error {
+	return vc.err
+}
+
+// Entries verifies that newTree is a consistent extension of the last tree
+// stored in the VerifyingClient's [CheckpointStore], advances the store to
+// newTree, and then behaves like [Client.Entries] over the verified newTree.
+//
+// If no tree has ever been stored, newTree is trusted on first use and
+// stored without a consistency check, matching how sumdb clients bootstrap
+// from an empty "latest" file.
+//
+// Callers must check [VerifyingClient.Err] after the iteration breaks.
+func (vc *VerifyingClient) Entries(ctx context.Context, newTree tlog.Tree, start int64) iter.Seq2[int64, []byte] {
+	vc.err = nil
+	return func(yield func(int64, []byte) bool) {
+		last, err := vc.store.ReadLatest(ctx)
+		if err != nil {
+			vc.err = fmt.Errorf("reading latest checkpoint: %w", err)
+			return
+		}
+
+		if last.N > 0 {
+			if newTree.N < last.N || (newTree.N == last.N && newTree.Hash != last.Hash) {
+				vc.err = fmt.Errorf("%w: new tree (size %d) is not an extension of the trusted tree (size %d)",
+					ErrLogFork, newTree.N, last.N)
+				return
+			}
+			if newTree.N > last.N {
+				r := TileHashReaderWithContext(ctx, newTree, vc.c.tr)
+				proof, err := tlog.ProveTree(newTree.N, last.N, r)
+				if err != nil {
+					vc.err = fmt.Errorf("computing consistency proof: %w", err)
+					return
+				}
+				if err := tlog.CheckTree(proof, newTree.N, newTree.Hash, last.N, last.Hash); err != nil {
+					vc.err = fmt.Errorf("%w: %v", ErrLogFork, err)
+					return
+				}
+			}
+		}
+
+		if newTree != last {
+			if err := vc.store.WriteLatest(ctx, newTree); err != nil {
+				vc.err = fmt.Errorf("writing latest checkpoint: %w", err)
+				return
+			}
+		}
+
+		for i, entry := range vc.c.Entries(ctx, newTree, start) {
+			if !yield(i, entry) {
+				return
+			}
+		}
+		if err := vc.c.Err(); err != nil {
+			vc.err = err
+		}
+	}
+}
+
+// FileCheckpointStore is a [CheckpointStore] that persists the latest
+// trusted tree as a single file, next to how [PermanentCache] persists
+// tiles in a filesystem directory.
+type FileCheckpointStore struct {
+	path string
+}
+
+// NewFileCheckpointStore creates a new [FileCheckpointStore] that persists
+// the latest trusted tree at path. The file is created on the first call to
+// WriteLatest; it does not need to exist beforehand.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+// ReadLatest implements [CheckpointStore].
+func (s *FileCheckpointStore) ReadLatest(ctx context.Context) (tlog.Tree, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tlog.Tree{}, nil
+	}
+	if err != nil {
+		return tlog.Tree{}, err
+	}
+	lines := strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)
+	if len(lines) != 2 {
+		return tlog.Tree{}, fmt.Errorf("malformed checkpoint file %q", s.path)
+	}
+	n, err := strconv.ParseInt(lines[0], 10, 64)
+	if err != nil {
+		return tlog.Tree{}, fmt.Errorf("malformed checkpoint file %q: %w", s.path, err)
+	}
+	h, err := tlog.ParseHash(lines[1])
+	if err != nil {
+		return tlog.Tree{}, fmt.Errorf("malformed checkpoint file %q: %w", s.path, err)
+	}
+	return tlog.Tree{N: n, Hash: h}, nil
+}
+
+// WriteLatest implements [CheckpointStore]. It writes the new tree to a
+// temporary file and renames it into place, so a crash can't leave a
+// partially written checkpoint behind.
+func (s *FileCheckpointStore) WriteLatest(ctx context.Context, tree tlog.Tree) error {
+	data := fmt.Sprintf("%d\n%s\n", tree.N, tree.Hash)
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(data), 0600); err != nil {
+		return fmt.Errorf("writing checkpoint file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("renaming checkpoint file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package torchwood
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+// memCheckpointStore is an in-memory [CheckpointStore] for tests.
+type memCheckpointStore struct {
+	tree tlog.Tree
+}
+
+func (s *memCheckpointStore) ReadLatest(ctx context.Context) (tlog.Tree, error) {
+	return s.tree, nil
+}
+
+func (s *memCheckpointStore) WriteLatest(ctx context.Context, tree tlog.Tree) error {
+	s.tree = tree
+	return nil
+}
+
+// mutableTiles lets a test swap out the [TileReaderWithContext] backing a
+// [VerifyingClient] between calls, to simulate a log whose served
+// content changes out from under a long-lived client.
+type mutableTiles struct {
+	cur *memTiles
+}
+
+func (m *mutableTiles) ReadTiles(ctx context.Context, tiles []tlog.Tile) ([][]byte, error) {
+	return m.cur.ReadTiles(ctx, tiles)
+}
+
+func (m *mutableTiles) SaveTiles(tiles []tlog.Tile, data [][]byte) {
+	m.cur.SaveTiles(tiles, data)
+}
+
+func TestVerifyingClientTrustsFirstTreeThenVerifiesGrowth(t *testing.T) {
+	n := 4
+	tree1, mt := newTestLog(t, "main", n)
+	tiles := &mutableTiles{cur: mt}
+	store := &memCheckpointStore{}
+
+	vc, err := NewVerifyingClient(tiles, store, WithSumDBEntries())
+	if err != nil {
+		t.Fatalf("NewVerifyingClient: %v", err)
+	}
+
+	for range vc.Entries(context.Background(), tree1, 0) {
+	}
+	if err := vc.Err(); err != nil {
+		t.Fatalf("Entries on first use: %v", err)
+	}
+	if store.tree != tree1 {
+		t.Fatalf("store did not trust the first tree on first use")
+	}
+
+	tree2, mt2 := newTestLog(t, "main", n+2)
+	tiles.cur = mt2
+
+	for range vc.Entries(context.Background(), tree2, 0) {
+	}
+	if err := vc.Err(); err != nil {
+		t.Fatalf("Entries on consistent growth: %v", err)
+	}
+	if store.tree != tree2 {
+		t.Fatalf("store did not advance to the verified larger tree")
+	}
+}
+
+// TestVerifyingClientDetectsForkAtSameSize simulates a log that serves a
+// different root hash for a tree of the same size it already vouched
+// for, the simplest form of a fork, and checks that the stored
+// checkpoint is left untouched.
+func TestVerifyingClientDetectsForkAtSameSize(t *testing.T) {
+	tree, mt := newTestLog(t, "main", 4)
+	tiles := &mutableTiles{cur: mt}
+	store := &memCheckpointStore{}
+
+	vc, err := NewVerifyingClient(tiles, store, WithSumDBEntries())
+	if err != nil {
+		t.Fatalf("NewVerifyingClient: %v", err)
+	}
+
+	for range vc.Entries(context.Background(), tree, 0) {
+	}
+	if err := vc.Err(); err != nil {
+		t.Fatalf("Entries on first use: %v", err)
+	}
+
+	forked := tree
+	forked.Hash[0] ^= 0xff
+
+	for range vc.Entries(context.Background(), forked, 0) {
+	}
+	if err := vc.Err(); !errors.Is(err, ErrLogFork) {
+		t.Fatalf("Entries err = %v, want ErrLogFork", err)
+	}
+	if store.tree != tree {
+		t.Fatalf("store advanced to an unverified forked tree")
+	}
+}
+
+// TestVerifyingClientDetectsForkOnGrowth simulates a log that, when
+// asked for a bigger tree, serves one that isn't actually an extension
+// of the tree the [VerifyingClient] already trusts: the consistency
+// proof can't check out, and [ErrLogFork] must be reported instead of
+// silently trusting the new tree.
+func TestVerifyingClientDetectsForkOnGrowth(t *testing.T) {
+	tree1, mt1 := newTestLog(t, "main", 4)
+	tiles := &mutableTiles{cur: mt1}
+	store := &memCheckpointStore{}
+
+	vc, err := NewVerifyingClient(tiles, store, WithSumDBEntries())
+	if err != nil {
+		t.Fatalf("NewVerifyingClient: %v", err)
+	}
+
+	for range vc.Entries(context.Background(), tree1, 0) {
+	}
+	if err := vc.Err(); err != nil {
+		t.Fatalf("Entries on first use: %v", err)
+	}
+
+	// An entirely independent log of a bigger size: same kind of content,
+	// but not built as an extension of tree1's leaves, so it can't carry a
+	// valid consistency proof from tree1.
+	tree2, mt2 := newTestLog(t, "fork", 6)
+	tiles.cur = mt2
+
+	for range vc.Entries(context.Background(), tree2, 0) {
+	}
+	if err := vc.Err(); !errors.Is(err, ErrLogFork) {
+		t.Fatalf("Entries err = %v, want ErrLogFork", err)
+	}
+	if store.tree != tree1 {
+		t.Fatalf("store advanced to an unverified forked tree")
+	}
+}
+
+func TestFileCheckpointStoreRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "latest")
+	s := NewFileCheckpointStore(path)
+
+	tree := tlog.Tree{N: 42, Hash: tlog.RecordHash([]byte("some tree"))}
+	if err := s.WriteLatest(context.Background(), tree); err != nil {
+		t.Fatalf("WriteLatest: %v", err)
+	}
+
+	got, err := s.ReadLatest(context.Background())
+	if err != nil {
+		t.Fatalf("ReadLatest: %v", err)
+	}
+	if got != tree {
+		t.Fatalf("ReadLatest = %+v, want %+v", got, tree)
+	}
+
+	// A second write must replace the first, not append to it.
+	tree2 := tlog.Tree{N: 43, Hash: tlog.RecordHash([]byte("a bigger tree"))}
+	if err := s.WriteLatest(context.Background(), tree2); err != nil {
+		t.Fatalf("WriteLatest: %v", err)
+	}
+	got, err = s.ReadLatest(context.Background())
+	if err != nil {
+		t.Fatalf("ReadLatest: %v", err)
+	}
+	if got != tree2 {
+		t.Fatalf("ReadLatest = %+v, want %+v", got, tree2)
+	}
+}
+
+func TestFileCheckpointStoreMissingFileReturnsZeroTree(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "does-not-exist")
+	s := NewFileCheckpointStore(path)
+
+	got, err := s.ReadLatest(context.Background())
+	if err != nil {
+		t.Fatalf("ReadLatest: %v", err)
+	}
+	if got != (tlog.Tree{}) {
+		t.Fatalf("ReadLatest = %+v, want the zero Tree", got)
+	}
+}
+
+func TestFileCheckpointStoreMalformedFileReturnsError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "latest")
+	if err := os.WriteFile(path, []byte("not a checkpoint"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	s := NewFileCheckpointStore(path)
+
+	if _, err := s.ReadLatest(context.Background()); err == nil {
+		t.Fatalf("ReadLatest succeeded on a malformed file, want an error")
+	}
+}